@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+)
+
+// deployRequest представляет JSON-данные из запроса на деплой.
+type deployRequest struct {
+	URL         string `json:"url"`
+	Branch      string `json:"branch"`
+	AccessToken string `json:"access_token"`
+}
+
+// deployResponse возвращается сразу после постановки деплоя в работу.
+type deployResponse struct {
+	ID string `json:"id"`
+}
+
+// handleDeploy ставит деплой проекта в очередь сборок и сразу возвращает его
+// ID. Прогресс деплоя можно затем получить через GET /deploy/{id}/events,
+// а отменить незавершённую сборку — через DELETE /deploy/{id}.
+func (h *Handler) handleDeploy(w http.ResponseWriter, r *http.Request) {
+	var req deployRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := url.Parse(req.URL); err != nil {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+
+	project := entity.Project{
+		URL:         req.URL,
+		Branch:      req.Branch,
+		AccessToken: req.AccessToken,
+	}
+
+	// Деплой переживает этот запрос, поэтому не наследуем его отмену —
+	// только значения контекста.
+	id, err := h.queue.Enqueue(context.WithoutCancel(r.Context()), project)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(deployResponse{ID: id})
+}
+
+// handleDeployProject ставит в очередь (пере)деплой уже зарегистрированного
+// проекта аутентифицированного пользователя. В отличие от handleDeploy,
+// требует владения проектом и берёт URL/ветку/секреты из хранилища, а не из
+// тела запроса, — так деплой всегда идёт по сохранённому Project.ID и, как и
+// вебхук-редеплой, сохраняет домен проекта и заменяет его прежний контейнер.
+func (h *Handler) handleDeployProject(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authenticate(r)
+	if err != nil {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	project, err := h.ownedProject(r, user.ID)
+	if err != nil {
+		writeProjectLookupError(w, err)
+		return
+	}
+
+	// Деплой переживает этот запрос, поэтому не наследуем его отмену —
+	// только значения контекста.
+	id, err := h.queue.Enqueue(context.WithoutCancel(r.Context()), project)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(deployResponse{ID: id})
+}
+
+// handleCancelDeploy отменяет сборку id — уже идущую или ещё ждущую своей
+// очереди из-за параллельной сборки того же проекта.
+func (h *Handler) handleCancelDeploy(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if !h.queue.Cancel(id) {
+		http.Error(w, "deploy not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
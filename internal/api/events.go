@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/service"
+)
+
+// handleDeployEvents транслирует прогресс деплоя клиенту через Server-Sent Events:
+// клон репозитория, вывод сборки образа, запуск контейнера и итоговый статус.
+// Несколько клиентов могут одновременно подписаться на один и тот же деплой.
+func (h *Handler) handleDeployEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	events, unsubscribe, ok := h.deploy.Subscribe(id)
+	if !ok {
+		http.Error(w, "deploy not found", http.StatusNotFound)
+		return
+	}
+	defer unsubscribe()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Поток живёт всю сборку, поэтому снимаем WriteTimeout сервера для этого ответа.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			b, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+
+			fmt.Fprintf(w, "data: %s\n\n", b)
+			flusher.Flush()
+
+			if event.Stage == service.StageDone || event.Stage == service.StageError {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
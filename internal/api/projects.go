@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gofrs/uuid/v5"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository"
+	"gitlab.com/anaxita-server/easy-deploy/internal/service"
+)
+
+// projectRequest представляет JSON-данные из запроса на создание или изменение проекта.
+type projectRequest struct {
+	URL           string `json:"url"`
+	Name          string `json:"name"`
+	Branch        string `json:"branch"`
+	Domain        string `json:"domain"`
+	AccessToken   string `json:"access_token"`
+	WebhookSecret string `json:"webhook_secret"`
+}
+
+// projectResponse отдаёт проект без AccessToken и WebhookSecret.
+type projectResponse struct {
+	ID                uuid.UUID `json:"id"`
+	UserID            uuid.UUID `json:"user_id"`
+	URL               string    `json:"url"`
+	Name              string    `json:"name"`
+	Branch            string    `json:"branch"`
+	DockerContainerID string    `json:"docker_container_id"`
+	Port              int       `json:"port"`
+	Domain            string    `json:"domain"`
+}
+
+// newProjectResponse редактирует секреты проекта перед отдачей клиенту.
+func newProjectResponse(p entity.Project) projectResponse {
+	return projectResponse{
+		ID:                p.ID,
+		UserID:            p.UserID,
+		URL:               p.URL,
+		Name:              p.Name,
+		Branch:            p.Branch,
+		DockerContainerID: p.DockerContainerID,
+		Port:              p.Port,
+		Domain:            p.Domain,
+	}
+}
+
+// handleCreateProject создаёт проект аутентифицированного пользователя.
+func (h *Handler) handleCreateProject(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authenticate(r)
+	if err != nil {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	var req projectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	project, err := h.users.CreateProject(r.Context(), user, entity.Project{
+		URL:           req.URL,
+		Name:          req.Name,
+		Branch:        req.Branch,
+		Domain:        req.Domain,
+		AccessToken:   req.AccessToken,
+		WebhookSecret: req.WebhookSecret,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrProjectQuotaExceeded) {
+			http.Error(w, "Project quota exceeded", http.StatusForbidden)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(newProjectResponse(project))
+}
+
+// handleListProjects возвращает все проекты аутентифицированного пользователя.
+func (h *Handler) handleListProjects(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authenticate(r)
+	if err != nil {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	projects, err := h.users.Projects(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]projectResponse, len(projects))
+	for i, p := range projects {
+		resp[i] = newProjectResponse(p)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleUpdateProject обновляет проект, если он принадлежит аутентифицированному пользователю.
+func (h *Handler) handleUpdateProject(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authenticate(r)
+	if err != nil {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	project, err := h.ownedProject(r, user.ID)
+	if err != nil {
+		writeProjectLookupError(w, err)
+		return
+	}
+
+	var req projectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	project.URL = req.URL
+	project.Name = req.Name
+	project.Branch = req.Branch
+	project.Domain = req.Domain
+
+	// projectResponse никогда не отдаёт секреты клиенту, поэтому обычный цикл
+	// "загрузить проект, поправить поле, отправить PUT обратно" всегда шлёт
+	// их пустыми. Перетирать хранимое значение пустой строкой в этом случае
+	// нельзя — так ломается доступ к приватному репозиторию и проверка
+	// подписи вебхуков. Менять секрет можно только явно передав новое значение.
+	if req.AccessToken != "" {
+		project.AccessToken = req.AccessToken
+	}
+	if req.WebhookSecret != "" {
+		project.WebhookSecret = req.WebhookSecret
+	}
+
+	if err := h.users.UpdateProject(r.Context(), project); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(newProjectResponse(project))
+}
+
+// handleDeleteProject удаляет проект, если он принадлежит аутентифицированному пользователю.
+func (h *Handler) handleDeleteProject(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authenticate(r)
+	if err != nil {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	project, err := h.ownedProject(r, user.ID)
+	if err != nil {
+		writeProjectLookupError(w, err)
+		return
+	}
+
+	if err := h.users.DeleteProject(r.Context(), project.ID); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ownedProject разбирает {id} из пути и возвращает проект, только если он принадлежит userID.
+func (h *Handler) ownedProject(r *http.Request, userID uuid.UUID) (entity.Project, error) {
+	id, err := uuid.FromString(r.PathValue("id"))
+	if err != nil {
+		return entity.Project{}, repository.ErrNotFound
+	}
+
+	project, err := h.users.Project(r.Context(), id)
+	if err != nil {
+		return entity.Project{}, err
+	}
+
+	if project.UserID != userID {
+		return entity.Project{}, repository.ErrNotFound
+	}
+
+	return project, nil
+}
+
+func writeProjectLookupError(w http.ResponseWriter, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	}
+
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
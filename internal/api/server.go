@@ -8,10 +8,10 @@ import (
 	"gitlab.com/anaxita-server/easy-deploy/internal/service"
 )
 
-func NewServer(port int, mux *http.ServeMux) *http.Server {
+func NewServer(port int, handler http.Handler) *http.Server {
 	return &http.Server{
 		Addr:              ":" + strconv.Itoa(port),
-		Handler:           mux,
+		Handler:           handler,
 		ReadTimeout:       time.Second * 5,
 		WriteTimeout:      time.Second * 5,
 		ReadHeaderTimeout: time.Second * 5,
@@ -20,16 +20,35 @@ func NewServer(port int, mux *http.ServeMux) *http.Server {
 
 func NewMux(h *Handler) *http.ServeMux {
 	mux := http.NewServeMux()
+	mux.HandleFunc("POST /deploy", h.handleDeploy)
+	mux.HandleFunc("GET /deploy/{id}/events", h.handleDeployEvents)
+	mux.HandleFunc("DELETE /deploy/{id}", h.handleCancelDeploy)
+
+	mux.HandleFunc("POST /users", h.handleCreateUser)
+	mux.HandleFunc("POST /login", h.handleLogin)
+
+	mux.HandleFunc("POST /projects", h.handleCreateProject)
+	mux.HandleFunc("GET /projects", h.handleListProjects)
+	mux.HandleFunc("PUT /projects/{id}", h.handleUpdateProject)
+	mux.HandleFunc("DELETE /projects/{id}", h.handleDeleteProject)
+	mux.HandleFunc("POST /projects/{id}/deploy", h.handleDeployProject)
+
+	mux.HandleFunc("POST /webhooks/github", h.handleGithubWebhook)
+	mux.HandleFunc("POST /webhooks/gitlab", h.handleGitlabWebhook)
 
 	return mux
 }
 
 type Handler struct {
 	deploy *service.Deploy
+	queue  *service.BuildQueue
+	users  *service.User
 }
 
-func NewHandler(deploy *service.Deploy) *Handler {
+func NewHandler(deploy *service.Deploy, queue *service.BuildQueue, users *service.User) *Handler {
 	return &Handler{
 		deploy: deploy,
+		queue:  queue,
+		users:  users,
 	}
 }
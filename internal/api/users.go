@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+	"gitlab.com/anaxita-server/easy-deploy/internal/service"
+)
+
+// userRequest представляет JSON-данные из запроса на регистрацию или вход.
+type userRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// userResponse отдаёт пользователя без хэша пароля.
+type userResponse struct {
+	ID    string      `json:"id"`
+	Email string      `json:"email"`
+	Plan  entity.Plan `json:"plan"`
+}
+
+// handleCreateUser регистрирует нового пользователя на PlanFree.
+func (h *Handler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.users.Register(r.Context(), req.Email, req.Password)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(userResponse{ID: user.ID.String(), Email: user.Email, Plan: user.Plan})
+}
+
+// handleLogin проверяет email и пароль пользователя.
+func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.users.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+			return
+		}
+
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(userResponse{ID: user.ID.String(), Email: user.Email, Plan: user.Plan})
+}
+
+// authenticate проверяет Basic Auth заголовок запроса против хранимых пользователей.
+func (h *Handler) authenticate(r *http.Request) (entity.User, error) {
+	email, password, ok := r.BasicAuth()
+	if !ok {
+		return entity.User{}, service.ErrInvalidCredentials
+	}
+
+	return h.users.Login(r.Context(), email, password)
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// githubPushPayload содержит только поля push-события GitHub, нужные для резолва проекта.
+type githubPushPayload struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		CloneURL string `json:"clone_url"`
+	} `json:"repository"`
+}
+
+// handleGithubWebhook принимает push-события GitHub, проверяет HMAC-подпись
+// X-Hub-Signature-256 секретом найденного проекта и ставит редеплой в работу.
+func (h *Handler) handleGithubWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("X-GitHub-Event") == "ping" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var payload githubPushPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	branch := strings.TrimPrefix(payload.Ref, "refs/heads/")
+
+	project, err := h.users.ProjectByURLAndBranch(r.Context(), payload.Repository.CloneURL, branch)
+	if err != nil {
+		writeProjectLookupError(w, err)
+		return
+	}
+
+	if !validGithubSignature(project.WebhookSecret, r.Header.Get("X-Hub-Signature-256"), body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	// Редеплой переживает этот запрос, поэтому не наследуем его отмену. Очередь
+	// сама схлопывает параллельные пуши в один ref в одну сборку.
+	if _, err := h.queue.Enqueue(context.WithoutCancel(r.Context()), project); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// gitlabPushPayload содержит только поля push-события GitLab, нужные для резолва проекта.
+type gitlabPushPayload struct {
+	Ref     string `json:"ref"`
+	Project struct {
+		GitHTTPURL string `json:"git_http_url"`
+	} `json:"project"`
+}
+
+// handleGitlabWebhook принимает push-события GitLab, проверяет токен
+// X-Gitlab-Token секретом найденного проекта и ставит редеплой в работу.
+func (h *Handler) handleGitlabWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("X-Gitlab-Event") != "Push Hook" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var payload gitlabPushPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	branch := strings.TrimPrefix(payload.Ref, "refs/heads/")
+
+	project, err := h.users.ProjectByURLAndBranch(r.Context(), payload.Project.GitHTTPURL, branch)
+	if err != nil {
+		writeProjectLookupError(w, err)
+		return
+	}
+
+	if !hmac.Equal([]byte(r.Header.Get("X-Gitlab-Token")), []byte(project.WebhookSecret)) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	if _, err := h.queue.Enqueue(context.WithoutCancel(r.Context()), project); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// validGithubSignature проверяет заголовок X-Hub-Signature-256 по HMAC-SHA256 от секрета проекта.
+func validGithubSignature(secret, header string, body []byte) bool {
+	if secret == "" || header == "" {
+		return false
+	}
+
+	const prefix = "sha256="
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(strings.TrimPrefix(header, prefix)), []byte(expected))
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func githubSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidGithubSignature(t *testing.T) {
+	secret := "top-secret"
+	body := []byte(`{"ref":"refs/heads/main"}`)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		want   bool
+	}{
+		{"valid signature", secret, githubSignature(secret, body), body, true},
+		{"wrong secret", "other-secret", githubSignature(secret, body), body, false},
+		{"tampered body", secret, githubSignature(secret, body), []byte(`{"ref":"refs/heads/evil"}`), false},
+		{"missing prefix", secret, hex.EncodeToString([]byte("deadbeef")), body, false},
+		{"empty secret", "", githubSignature(secret, body), body, false},
+		{"empty header", secret, "", body, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validGithubSignature(tt.secret, tt.header, tt.body); got != tt.want {
+				t.Errorf("validGithubSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
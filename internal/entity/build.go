@@ -0,0 +1,20 @@
+package entity
+
+import "github.com/gofrs/uuid/v5"
+
+// BuildStatus отражает состояние сборки, пока она не завершилась. Финальные
+// исходы (успех, ошибка, отмена) не хранятся — запись просто удаляется.
+type BuildStatus string
+
+const (
+	BuildStatusQueued  BuildStatus = "queued"
+	BuildStatusRunning BuildStatus = "running"
+)
+
+// Build — персистентная запись о сборке, ещё не дошедшей до конца. По ней
+// BuildQueue подхватывает недоделанную работу после перезапуска процесса.
+type Build struct {
+	ID        string      `json:"id"`
+	ProjectID uuid.UUID   `json:"project_id"`
+	Status    BuildStatus `json:"status"`
+}
@@ -6,10 +6,13 @@ import (
 
 type Project struct {
 	ID                uuid.UUID `json:"id"`                  // Генерируем автоматически
+	UserID            uuid.UUID `json:"user_id"`             // Владелец проекта
 	URL               string    `json:"url"`                 // Ссылка на репозиторий
 	Name              string    `json:"name"`                // Берём из ссылки на репозиторий
 	Branch            string    `json:"branch"`              // Ветка репозитория которую деплоим
 	DockerContainerID string    `json:"docker_container_id"` // ID запущенного контейнера
+	Port              int       `json:"port"`                // Порт хоста, зарезервированный под DockerContainerID
 	Domain            string    `json:"domain"`              // Случайный домен для доступа к контейнеру
 	AccessToken       string    `json:"access_token"`        // Токен доступа к репозиторию, если репозиторий приватный
+	WebhookSecret     string    `json:"webhook_secret"`      // Секрет для проверки подписи push-вебхуков
 }
@@ -17,4 +17,3 @@ type User struct {
 	Password string    `json:"password"` // Хешируем
 	Plan     Plan      `json:"plan"`     // По умолчанию PlanFree
 }
-0
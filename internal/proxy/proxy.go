@@ -0,0 +1,106 @@
+// Package proxy маршрутизирует входящий трафик по Host-заголовку на контейнер
+// соответствующего проекта и выпускает TLS-сертификаты по требованию через ACME.
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Proxy хранит таблицу маршрутизации домен -> адрес контейнера и обновляет её атомарно.
+type Proxy struct {
+	mu     sync.RWMutex
+	routes map[string]*httputil.ReverseProxy
+
+	certManager *autocert.Manager
+}
+
+// New создаёт прокси, кэширующий сертификаты autocert в certCacheDir.
+func New(certCacheDir string) *Proxy {
+	p := &Proxy{
+		routes: make(map[string]*httputil.ReverseProxy),
+	}
+
+	p.certManager = &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: p.hostPolicy,
+		Cache:      autocert.DirCache(certCacheDir),
+	}
+
+	return p
+}
+
+// hostPolicy разрешает выпуск сертификата только для доменов с известным маршрутом.
+func (p *Proxy) hostPolicy(_ context.Context, host string) error {
+	p.mu.RLock()
+	_, ok := p.routes[host]
+	p.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("unknown domain: %s", host)
+	}
+
+	return nil
+}
+
+// SetRoute атомарно направляет domain на backendAddr (host:port контейнера).
+// Вызывается после health-check нового контейнера, поэтому переключение
+// происходит без окна простоя.
+func (p *Proxy) SetRoute(domain, backendAddr string) error {
+	target, err := url.Parse("http://" + backendAddr)
+	if err != nil {
+		return fmt.Errorf("failed to parse backend address: %w", err)
+	}
+
+	rp := httputil.NewSingleHostReverseProxy(target)
+
+	p.mu.Lock()
+	p.routes[domain] = rp
+	p.mu.Unlock()
+
+	return nil
+}
+
+// RemoveRoute убирает маршрут домена, например при удалении проекта.
+func (p *Proxy) RemoveRoute(domain string) {
+	p.mu.Lock()
+	delete(p.routes, domain)
+	p.mu.Unlock()
+}
+
+// ServeHTTP диспетчеризует запрос по Host-заголовку на контейнер соответствующего проекта.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	p.mu.RLock()
+	rp, ok := p.routes[host]
+	p.mu.RUnlock()
+
+	if !ok {
+		http.Error(w, "unknown domain", http.StatusNotFound)
+		return
+	}
+
+	rp.ServeHTTP(w, r)
+}
+
+// TLSConfig возвращает tls.Config для слушателя на 443, выпускающего сертификаты по требованию.
+func (p *Proxy) TLSConfig() *tls.Config {
+	return p.certManager.TLSConfig()
+}
+
+// HTTPHandler оборачивает fallback обработчиком ACME http-01 challenge для слушателя на 80.
+func (p *Proxy) HTTPHandler(fallback http.Handler) http.Handler {
+	return p.certManager.HTTPHandler(fallback)
+}
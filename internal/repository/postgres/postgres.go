@@ -0,0 +1,304 @@
+// Package postgres реализует repository.Repository поверх Postgres через pgx.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository/sqlstore"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	id       TEXT PRIMARY KEY,
+	email    TEXT NOT NULL UNIQUE,
+	password TEXT NOT NULL,
+	plan     SMALLINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id                  TEXT PRIMARY KEY,
+	user_id             TEXT NOT NULL,
+	url                 TEXT NOT NULL,
+	name                TEXT NOT NULL,
+	branch              TEXT NOT NULL DEFAULT '',
+	docker_container_id TEXT NOT NULL DEFAULT '',
+	port                INTEGER NOT NULL DEFAULT 0,
+	domain              TEXT NOT NULL DEFAULT '',
+	access_token        TEXT NOT NULL DEFAULT '',
+	webhook_secret      TEXT NOT NULL DEFAULT ''
+);
+
+-- reserved_ports хранит порты хоста, уже занятые под запущенные контейнеры,
+-- чтобы ReservePort выбирал свободный порт атомарно, без окна между проверкой
+-- и docker run.
+CREATE TABLE IF NOT EXISTS reserved_ports (
+	port INTEGER PRIMARY KEY
+);
+
+-- builds хранит сборки, ещё не дошедшие до конца, чтобы BuildQueue могла
+-- продолжить их после перезапуска процесса.
+CREATE TABLE IF NOT EXISTS builds (
+	id         TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL,
+	status     TEXT NOT NULL
+);
+`
+
+// portReservationLockID — произвольный ключ advisory-лока Postgres, сериализующий
+// ReservePort: в отличие от занятых портов, свободные port-ы не существуют как
+// строки, которые можно было бы заблокировать SELECT ... FOR UPDATE.
+const portReservationLockID = 72173
+
+// Repository хранит пользователей и проекты в Postgres.
+type Repository struct {
+	db *sql.DB
+}
+
+var _ repository.Repository = (*Repository)(nil)
+
+// New открывает пул соединений с Postgres по dsn и накатывает схему.
+func New(dsn string) (*Repository, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres db: %w", err)
+	}
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to apply schema: %w", err)
+	}
+
+	return &Repository{db: db}, nil
+}
+
+// Close закрывает пул соединений.
+func (r *Repository) Close() error {
+	return r.db.Close()
+}
+
+func (r *Repository) CreateUser(ctx context.Context, user entity.User) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO users (id, email, password, plan) VALUES ($1, $2, $3, $4)`,
+		user.ID.String(), user.Email, user.Password, user.Plan)
+	if err != nil {
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) UserByEmail(ctx context.Context, email string) (entity.User, error) {
+	var (
+		user  entity.User
+		rawID string
+	)
+
+	row := r.db.QueryRowContext(ctx, `SELECT id, email, password, plan FROM users WHERE email = $1`, email)
+	if err := row.Scan(&rawID, &user.Email, &user.Password, &user.Plan); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.User{}, repository.ErrNotFound
+		}
+
+		return entity.User{}, fmt.Errorf("failed to query user: %w", err)
+	}
+
+	id, err := uuid.FromString(rawID)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("failed to parse user id: %w", err)
+	}
+	user.ID = id
+
+	return user, nil
+}
+
+func (r *Repository) CreateProject(ctx context.Context, project entity.Project) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO projects (id, user_id, url, name, branch, docker_container_id, port, domain, access_token, webhook_secret)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		project.ID.String(), project.UserID.String(), project.URL, project.Name,
+		project.Branch, project.DockerContainerID, project.Port, project.Domain, project.AccessToken, project.WebhookSecret)
+	if err != nil {
+		return fmt.Errorf("failed to insert project: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) ProjectByID(ctx context.Context, id uuid.UUID) (entity.Project, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, url, name, branch, docker_container_id, port, domain, access_token, webhook_secret
+		 FROM projects WHERE id = $1`, id.String())
+
+	return sqlstore.ScanProject(row)
+}
+
+func (r *Repository) ProjectByURLAndBranch(ctx context.Context, url, branch string) (entity.Project, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, url, name, branch, docker_container_id, port, domain, access_token, webhook_secret
+		 FROM projects WHERE url = $1 AND branch = $2`, url, branch)
+
+	return sqlstore.ScanProject(row)
+}
+
+func (r *Repository) ProjectsByUserID(ctx context.Context, userID uuid.UUID) ([]entity.Project, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, url, name, branch, docker_container_id, port, domain, access_token, webhook_secret
+		 FROM projects WHERE user_id = $1`, userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []entity.Project
+	for rows.Next() {
+		project, err := sqlstore.ScanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
+
+func (r *Repository) UpdateProject(ctx context.Context, project entity.Project) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE projects SET url = $1, name = $2, branch = $3, docker_container_id = $4, port = $5, domain = $6, access_token = $7, webhook_secret = $8
+		 WHERE id = $9`,
+		project.URL, project.Name, project.Branch, project.DockerContainerID, project.Port, project.Domain,
+		project.AccessToken, project.WebhookSecret, project.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return sqlstore.CheckRowsAffected(res)
+}
+
+func (r *Repository) DeleteProject(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM projects WHERE id = $1`, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	return sqlstore.CheckRowsAffected(res)
+}
+
+func (r *Repository) CountProjectsByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+
+	row := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM projects WHERE user_id = $1`, userID.String())
+	if err := row.Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count projects: %w", err)
+	}
+
+	return count, nil
+}
+
+// ReservePort выбирает первый свободный порт в [sqlstore.PortRangeStart,
+// sqlstore.PortRangeEnd]. Берёт advisory-лок на время транзакции, чтобы две
+// параллельные резервации не выбрали один и тот же порт, прежде чем он
+// попадёт в reserved_ports.
+func (r *Repository) ReservePort(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, portReservationLockID); err != nil {
+		return 0, fmt.Errorf("failed to acquire port reservation lock: %w", err)
+	}
+
+	port, err := sqlstore.ReserveFreePort(ctx, tx, func(ctx context.Context, port int) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO reserved_ports (port) VALUES ($1)`, port)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit port reservation: %w", err)
+	}
+
+	return port, nil
+}
+
+// ReleasePort возвращает port в пул свободных. Освобождение несуществующей
+// резервации не ошибка — идемпотентно, как и положено освобождению ресурса.
+func (r *Repository) ReleasePort(ctx context.Context, port int) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM reserved_ports WHERE port = $1`, port); err != nil {
+		return fmt.Errorf("failed to release port: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) CreateBuild(ctx context.Context, build entity.Build) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO builds (id, project_id, status) VALUES ($1, $2, $3)`,
+		build.ID, build.ProjectID.String(), string(build.Status))
+	if err != nil {
+		return fmt.Errorf("failed to insert build: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateBuildStatus(ctx context.Context, id string, status entity.BuildStatus) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE builds SET status = $1 WHERE id = $2`, string(status), id)
+	if err != nil {
+		return fmt.Errorf("failed to update build status: %w", err)
+	}
+
+	return sqlstore.CheckRowsAffected(res)
+}
+
+func (r *Repository) DeleteBuild(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM builds WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete build: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) PendingBuilds(ctx context.Context) ([]entity.Build, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, project_id, status FROM builds`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending builds: %w", err)
+	}
+	defer rows.Close()
+
+	var builds []entity.Build
+	for rows.Next() {
+		var (
+			build      entity.Build
+			rawProject string
+			status     string
+		)
+
+		if err := rows.Scan(&build.ID, &rawProject, &status); err != nil {
+			return nil, fmt.Errorf("failed to scan pending build: %w", err)
+		}
+
+		projectID, err := uuid.FromString(rawProject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse build project id: %w", err)
+		}
+		build.ProjectID = projectID
+		build.Status = entity.BuildStatus(status)
+
+		builds = append(builds, build)
+	}
+
+	return builds, rows.Err()
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofrs/uuid/v5"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+)
+
+// ErrNotFound возвращается, когда запрошенная запись отсутствует в хранилище.
+var ErrNotFound = errors.New("not found")
+
+// Repository хранит пользователей и их проекты. Реализации: sqlite (zero-cgo,
+// по умолчанию) и postgres.
+type Repository interface {
+	CreateUser(ctx context.Context, user entity.User) error
+	UserByEmail(ctx context.Context, email string) (entity.User, error)
+
+	CreateProject(ctx context.Context, project entity.Project) error
+	ProjectByID(ctx context.Context, id uuid.UUID) (entity.Project, error)
+	ProjectByURLAndBranch(ctx context.Context, url, branch string) (entity.Project, error)
+	ProjectsByUserID(ctx context.Context, userID uuid.UUID) ([]entity.Project, error)
+	UpdateProject(ctx context.Context, project entity.Project) error
+	DeleteProject(ctx context.Context, id uuid.UUID) error
+	CountProjectsByUserID(ctx context.Context, userID uuid.UUID) (int, error)
+
+	// ReservePort и ReleasePort держат пул портов хоста под запущенные контейнеры.
+	// Резервирование и выбор свободного порта происходят атомарно в хранилище,
+	// чтобы между проверкой и docker run не было гонки за один и тот же порт.
+	ReservePort(ctx context.Context) (int, error)
+	ReleasePort(ctx context.Context, port int) error
+
+	// CreateBuild, UpdateBuildStatus, DeleteBuild и PendingBuilds persist-ят
+	// состояние BuildQueue, чтобы она могла возобновить незавершённые сборки
+	// после перезапуска процесса.
+	CreateBuild(ctx context.Context, build entity.Build) error
+	UpdateBuildStatus(ctx context.Context, id string, status entity.BuildStatus) error
+	DeleteBuild(ctx context.Context, id string) error
+	PendingBuilds(ctx context.Context) ([]entity.Build, error)
+}
@@ -0,0 +1,308 @@
+// Package sqlite реализует repository.Repository поверх modernc.org/sqlite —
+// это чистый Go драйвер без cgo, удобный как хранилище по умолчанию.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gofrs/uuid/v5"
+	_ "modernc.org/sqlite"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository/sqlstore"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	id       TEXT PRIMARY KEY,
+	email    TEXT NOT NULL UNIQUE,
+	password TEXT NOT NULL,
+	plan     INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id                  TEXT PRIMARY KEY,
+	user_id             TEXT NOT NULL,
+	url                 TEXT NOT NULL,
+	name                TEXT NOT NULL,
+	branch              TEXT NOT NULL DEFAULT '',
+	docker_container_id TEXT NOT NULL DEFAULT '',
+	port                INTEGER NOT NULL DEFAULT 0,
+	domain              TEXT NOT NULL DEFAULT '',
+	access_token        TEXT NOT NULL DEFAULT '',
+	webhook_secret      TEXT NOT NULL DEFAULT ''
+);
+
+-- reserved_ports хранит порты хоста, уже занятые под запущенные контейнеры,
+-- чтобы ReservePort выбирал свободный порт атомарно, без окна между проверкой
+-- и docker run.
+CREATE TABLE IF NOT EXISTS reserved_ports (
+	port INTEGER PRIMARY KEY
+);
+
+-- builds хранит сборки, ещё не дошедшие до конца, чтобы BuildQueue могла
+-- продолжить их после перезапуска процесса.
+CREATE TABLE IF NOT EXISTS builds (
+	id         TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL,
+	status     TEXT NOT NULL
+);
+`
+
+// Repository хранит пользователей и проекты в файле SQLite.
+type Repository struct {
+	db *sql.DB
+
+	// portMu сериализует ReservePort: SQLite открывает транзакции в
+	// deferred-режиме, поэтому без дополнительной блокировки два конкурентных
+	// BeginTx/SELECT успевают прочитать один и тот же "первый свободный" порт
+	// прежде, чем любой из них закоммитится. В Postgres ту же роль играет
+	// advisory-лок в транзакции; здесь процесс один, поэтому хватает мьютекса.
+	portMu sync.Mutex
+}
+
+var _ repository.Repository = (*Repository)(nil)
+
+// New открывает (и при необходимости создаёт) базу по пути dsn и накатывает схему.
+func New(dsn string) (*Repository, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite db: %w", err)
+	}
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to apply schema: %w", err)
+	}
+
+	return &Repository{db: db}, nil
+}
+
+// Close закрывает соединение с базой.
+func (r *Repository) Close() error {
+	return r.db.Close()
+}
+
+func (r *Repository) CreateUser(ctx context.Context, user entity.User) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO users (id, email, password, plan) VALUES (?, ?, ?, ?)`,
+		user.ID.String(), user.Email, user.Password, user.Plan)
+	if err != nil {
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) UserByEmail(ctx context.Context, email string) (entity.User, error) {
+	var (
+		user  entity.User
+		rawID string
+	)
+
+	row := r.db.QueryRowContext(ctx, `SELECT id, email, password, plan FROM users WHERE email = ?`, email)
+	if err := row.Scan(&rawID, &user.Email, &user.Password, &user.Plan); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.User{}, repository.ErrNotFound
+		}
+
+		return entity.User{}, fmt.Errorf("failed to query user: %w", err)
+	}
+
+	id, err := uuid.FromString(rawID)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("failed to parse user id: %w", err)
+	}
+	user.ID = id
+
+	return user, nil
+}
+
+func (r *Repository) CreateProject(ctx context.Context, project entity.Project) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO projects (id, user_id, url, name, branch, docker_container_id, port, domain, access_token, webhook_secret)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		project.ID.String(), project.UserID.String(), project.URL, project.Name,
+		project.Branch, project.DockerContainerID, project.Port, project.Domain, project.AccessToken, project.WebhookSecret)
+	if err != nil {
+		return fmt.Errorf("failed to insert project: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) ProjectByID(ctx context.Context, id uuid.UUID) (entity.Project, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, url, name, branch, docker_container_id, port, domain, access_token, webhook_secret
+		 FROM projects WHERE id = ?`, id.String())
+
+	return sqlstore.ScanProject(row)
+}
+
+func (r *Repository) ProjectByURLAndBranch(ctx context.Context, url, branch string) (entity.Project, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, url, name, branch, docker_container_id, port, domain, access_token, webhook_secret
+		 FROM projects WHERE url = ? AND branch = ?`, url, branch)
+
+	return sqlstore.ScanProject(row)
+}
+
+func (r *Repository) ProjectsByUserID(ctx context.Context, userID uuid.UUID) ([]entity.Project, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, url, name, branch, docker_container_id, port, domain, access_token, webhook_secret
+		 FROM projects WHERE user_id = ?`, userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []entity.Project
+	for rows.Next() {
+		project, err := sqlstore.ScanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
+
+func (r *Repository) UpdateProject(ctx context.Context, project entity.Project) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE projects SET url = ?, name = ?, branch = ?, docker_container_id = ?, port = ?, domain = ?, access_token = ?, webhook_secret = ?
+		 WHERE id = ?`,
+		project.URL, project.Name, project.Branch, project.DockerContainerID, project.Port, project.Domain,
+		project.AccessToken, project.WebhookSecret, project.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return sqlstore.CheckRowsAffected(res)
+}
+
+func (r *Repository) DeleteProject(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM projects WHERE id = ?`, id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	return sqlstore.CheckRowsAffected(res)
+}
+
+func (r *Repository) CountProjectsByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+
+	row := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM projects WHERE user_id = ?`, userID.String())
+	if err := row.Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count projects: %w", err)
+	}
+
+	return count, nil
+}
+
+// ReservePort выбирает первый свободный порт в [sqlstore.PortRangeStart,
+// sqlstore.PortRangeEnd] и сразу помечает его занятым в той же транзакции,
+// чтобы проверка и резервирование были атомарны. portMu сериализует вызовы
+// в рамках процесса — SQLite сам по себе не гарантирует этого для deferred-
+// транзакций.
+func (r *Repository) ReservePort(ctx context.Context) (int, error) {
+	r.portMu.Lock()
+	defer r.portMu.Unlock()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	port, err := sqlstore.ReserveFreePort(ctx, tx, func(ctx context.Context, port int) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO reserved_ports (port) VALUES (?)`, port)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit port reservation: %w", err)
+	}
+
+	return port, nil
+}
+
+// ReleasePort возвращает port в пул свободных. Освобождение несуществующей
+// резервации не ошибка — идемпотентно, как и положено освобождению ресурса.
+func (r *Repository) ReleasePort(ctx context.Context, port int) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM reserved_ports WHERE port = ?`, port); err != nil {
+		return fmt.Errorf("failed to release port: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) CreateBuild(ctx context.Context, build entity.Build) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO builds (id, project_id, status) VALUES (?, ?, ?)`,
+		build.ID, build.ProjectID.String(), string(build.Status))
+	if err != nil {
+		return fmt.Errorf("failed to insert build: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateBuildStatus(ctx context.Context, id string, status entity.BuildStatus) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE builds SET status = ? WHERE id = ?`, string(status), id)
+	if err != nil {
+		return fmt.Errorf("failed to update build status: %w", err)
+	}
+
+	return sqlstore.CheckRowsAffected(res)
+}
+
+func (r *Repository) DeleteBuild(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM builds WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete build: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) PendingBuilds(ctx context.Context) ([]entity.Build, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, project_id, status FROM builds`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending builds: %w", err)
+	}
+	defer rows.Close()
+
+	var builds []entity.Build
+	for rows.Next() {
+		var (
+			build      entity.Build
+			rawProject string
+			status     string
+		)
+
+		if err := rows.Scan(&build.ID, &rawProject, &status); err != nil {
+			return nil, fmt.Errorf("failed to scan pending build: %w", err)
+		}
+
+		projectID, err := uuid.FromString(rawProject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse build project id: %w", err)
+		}
+		build.ProjectID = projectID
+		build.Status = entity.BuildStatus(status)
+
+		builds = append(builds, build)
+	}
+
+	return builds, rows.Err()
+}
@@ -0,0 +1,127 @@
+// Package sqlstore собирает код, общий для sqlite- и postgres-реализаций
+// repository.Repository: сканирование Project, проверку rows affected и
+// перебор свободного порта. Он не открывает соединений и не знает о
+// конкретном драйвере — placeholder'ы для параметров SQL передаются вызывающей
+// стороной, а открытие транзакции и блокировки (advisory lock в Postgres,
+// мьютекс процесса в SQLite) остаются на ней, потому что там драйверы реально
+// расходятся.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository"
+)
+
+// PortRangeStart и PortRangeEnd ограничивают пул портов, из которого
+// ReserveFreePort выбирает свободный порт хоста для контейнеров.
+const (
+	PortRangeStart = 3000
+	PortRangeEnd   = 65535
+)
+
+// Scanner объединяет *sql.Row и *sql.Rows, что позволяет переиспользовать
+// ScanProject для обоих.
+type Scanner interface {
+	Scan(dest ...any) error
+}
+
+// ScanProject читает колонки
+// id, user_id, url, name, branch, docker_container_id, port, domain, access_token, webhook_secret
+// в entity.Project — в этом порядке оба драйвера и строят SELECT.
+func ScanProject(row Scanner) (entity.Project, error) {
+	var (
+		project entity.Project
+		rawID   string
+		rawUser string
+	)
+
+	if err := row.Scan(&rawID, &rawUser, &project.URL, &project.Name, &project.Branch,
+		&project.DockerContainerID, &project.Port, &project.Domain, &project.AccessToken, &project.WebhookSecret); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Project{}, repository.ErrNotFound
+		}
+
+		return entity.Project{}, fmt.Errorf("failed to scan project: %w", err)
+	}
+
+	id, err := uuid.FromString(rawID)
+	if err != nil {
+		return entity.Project{}, fmt.Errorf("failed to parse project id: %w", err)
+	}
+	project.ID = id
+
+	userID, err := uuid.FromString(rawUser)
+	if err != nil {
+		return entity.Project{}, fmt.Errorf("failed to parse project user id: %w", err)
+	}
+	project.UserID = userID
+
+	return project, nil
+}
+
+// CheckRowsAffected превращает "0 строк затронуто" в repository.ErrNotFound —
+// общий хвост для UPDATE/DELETE по id в обоих драйверах.
+func CheckRowsAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// ReserveFreePort ищет первый свободный порт в [PortRangeStart, PortRangeEnd]
+// и вставляет его в reserved_ports той же транзакцией — так проверка и
+// резервирование остаются атомарны. insertPort вызывается ровно один раз, с
+// найденным портом, и должен сформировать и выполнить INSERT с placeholder'ом,
+// принятым драйвером вызывающей стороны (tx.ExecContext(ctx, "... ($1)", port)
+// для Postgres, "... (?)" для SQLite). Сериализация от гонки двух параллельных
+// резерваций (advisory lock, мьютекс процесса) — забота вызывающей стороны,
+// этот код предполагает, что транзакция уже её обеспечила.
+func ReserveFreePort(ctx context.Context, tx *sql.Tx, insertPort func(ctx context.Context, port int) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT port FROM reserved_ports`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query reserved ports: %w", err)
+	}
+
+	taken := make(map[int]bool)
+	for rows.Next() {
+		var port int
+		if err := rows.Scan(&port); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan reserved port: %w", err)
+		}
+
+		taken[port] = true
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read reserved ports: %w", err)
+	}
+
+	for port := PortRangeStart; port <= PortRangeEnd; port++ {
+		if taken[port] {
+			continue
+		}
+
+		if err := insertPort(ctx, port); err != nil {
+			return 0, fmt.Errorf("failed to reserve port: %w", err)
+		}
+
+		return port, nil
+	}
+
+	return 0, fmt.Errorf("no free ports in range %d-%d", PortRangeStart, PortRangeEnd)
+}
@@ -0,0 +1,79 @@
+package service
+
+import "sync"
+
+// DeployEvent описывает один шаг прогресса деплоя, отправляемый подписчикам.
+type DeployEvent struct {
+	Stage          string          `json:"stage"`
+	Stream         string          `json:"stream,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	ProgressDetail *ProgressDetail `json:"progressDetail,omitempty"`
+	Time           int64           `json:"time"`
+}
+
+// ProgressDetail отражает прогресс закачки/распаковки отдельного слоя образа.
+type ProgressDetail struct {
+	Current int64 `json:"current,omitempty"`
+	Total   int64 `json:"total,omitempty"`
+}
+
+// Стадии, через которые проходит деплой; используются как значение DeployEvent.Stage.
+const (
+	StageClone = "clone"
+	StageBuild = "build"
+	StageRun   = "run"
+	StageDone  = "done"
+	StageError = "error"
+)
+
+// broker раздаёт события одного деплоя всем его подписчикам. Медленному
+// подписчику события молча отбрасываются вместо того, чтобы блокировать
+// остальных подписчиков или сам деплой. Финальное событие (StageDone или
+// StageError) запоминается и отдаётся подписчику, пришедшему уже после него.
+type broker struct {
+	mu    sync.Mutex
+	subs  map[chan DeployEvent]struct{}
+	final *DeployEvent
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[chan DeployEvent]struct{})}
+}
+
+func (b *broker) subscribe() chan DeployEvent {
+	ch := make(chan DeployEvent, 16)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	if b.final != nil {
+		ch <- *b.final
+	}
+	b.mu.Unlock()
+
+	return ch
+}
+
+func (b *broker) unsubscribe(ch chan DeployEvent) {
+	b.mu.Lock()
+	delete(b.subs, ch)
+	b.mu.Unlock()
+
+	close(ch)
+}
+
+func (b *broker) publish(event DeployEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.Stage == StageDone || event.Stage == StageError {
+		b.final = &event
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			// медленный подписчик отстал — пропускаем событие, а не блокируем деплой
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBrokerPublishDropsOnFullChannel(t *testing.T) {
+	b := newBroker()
+	sub := b.subscribe()
+
+	// Канал подписчика вместимостью 16 — забиваем его под завязку, не читая,
+	// чтобы следующая публикация встретила полный буфер.
+	for i := 0; i < cap(sub); i++ {
+		b.publish(DeployEvent{Stage: StageBuild})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.publish(DeployEvent{Stage: StageDone})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked on a full subscriber channel instead of dropping the event")
+	}
+
+	if len(sub) != cap(sub) {
+		t.Fatalf("expected channel to stay full at %d, got %d", cap(sub), len(sub))
+	}
+}
+
+func TestBrokerUnsubscribeStopsDelivery(t *testing.T) {
+	b := newBroker()
+	sub := b.subscribe()
+
+	b.unsubscribe(sub)
+
+	if _, ok := <-sub; ok {
+		t.Fatal("expected channel to be closed after unsubscribe")
+	}
+}
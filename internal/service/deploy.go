@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/go-connections/nat"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/gofrs/uuid/v5"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+	"gitlab.com/anaxita-server/easy-deploy/internal/proxy"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository"
+)
+
+// healthCheckInterval и healthCheckAttempts ограничивают, сколько времени
+// даётся свежезапущенному контейнеру на ответ по "/", прежде чем деплой
+// считается неудавшимся.
+const (
+	healthCheckInterval = 500 * time.Millisecond
+	healthCheckAttempts = 20
+)
+
+// brokerTTL — сколько брокер событий деплоя живёт после его завершения.
+// Нужен, чтобы клиент, подключившийся к /deploy/{id}/events чуть позже
+// старта, всё ещё застал финальное событие.
+const brokerTTL = 30 * time.Second
+
+// Deploy клонирует репозиторий проекта, собирает Docker-образ из его Dockerfile
+// и (пере)запускает контейнер через Docker Engine API, публикуя прогресс
+// подписчикам через per-деплой брокер событий.
+type Deploy struct {
+	docker *client.Client
+	repo   repository.Repository
+	proxy  *proxy.Proxy
+
+	mu      sync.Mutex
+	brokers map[string]*broker
+
+	cacheMu    sync.Mutex
+	cacheLocks map[string]*sync.Mutex
+}
+
+// NewDeploy создаёт сервис деплоя поверх переданного Docker-клиента и репозитория,
+// в котором хранится итоговый DockerContainerID проекта. rp может быть nil,
+// тогда проект остаётся доступен только по порту, без обратного прокси и домена.
+func NewDeploy(docker *client.Client, repo repository.Repository, rp *proxy.Proxy) *Deploy {
+	return &Deploy{
+		docker:     docker,
+		repo:       repo,
+		proxy:      rp,
+		brokers:    make(map[string]*broker),
+		cacheLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+// Run прогоняет клонирование, сборку и запуск контейнера под переданным id —
+// он должен быть сгенерирован и уникален на стороне вызывающего (в обычном
+// случае — BuildQueue, которая также ограничивает конкурентность и даёт
+// отменить деплой через ctx). Вызывается синхронно: воркер-пул и фоновый
+// запуск — забота вызывающего.
+func (d *Deploy) Run(ctx context.Context, id string, project entity.Project) {
+	b := newBroker()
+
+	d.mu.Lock()
+	d.brokers[id] = b
+	d.mu.Unlock()
+
+	d.process(ctx, id, b, project)
+}
+
+// Subscribe возвращает канал событий деплоя id и функцию отписки. ok == false,
+// если деплоя с таким ID нет — он либо никогда не запускался, либо его брокер
+// уже истёк по brokerTTL.
+func (d *Deploy) Subscribe(id string) (events <-chan DeployEvent, unsubscribe func(), ok bool) {
+	d.mu.Lock()
+	b, ok := d.brokers[id]
+	d.mu.Unlock()
+
+	if !ok {
+		return nil, nil, false
+	}
+
+	sub := b.subscribe()
+
+	return sub, func() { b.unsubscribe(sub) }, true
+}
+
+// process прогоняет деплой до конца, публикуя события в b, и освобождает
+// брокер по истечении brokerTTL.
+func (d *Deploy) process(ctx context.Context, id string, b *broker, project entity.Project) {
+	defer time.AfterFunc(brokerTTL, func() {
+		d.mu.Lock()
+		delete(d.brokers, id)
+		d.mu.Unlock()
+	})
+
+	project, err := d.cloneAndBuild(ctx, b, project)
+	if err != nil {
+		b.publish(DeployEvent{Stage: StageError, Error: err.Error(), Time: time.Now().Unix()})
+		return
+	}
+
+	b.publish(DeployEvent{
+		Stage:  StageDone,
+		Stream: fmt.Sprintf("container %s is up", project.DockerContainerID),
+		Time:   time.Now().Unix(),
+	})
+}
+
+// cloneAndBuild клонирует репозиторий проекта, собирает образ из его Dockerfile
+// и (пере)запускает контейнер, возвращая проект с обновлённым DockerContainerID.
+func (d *Deploy) cloneAndBuild(ctx context.Context, b *broker, project entity.Project) (entity.Project, error) {
+	if d.repo != nil && project.ID != uuid.Nil {
+		stored, err := d.repo.ProjectByID(ctx, project.ID)
+		if err != nil {
+			return project, fmt.Errorf("failed to load project: %w", err)
+		}
+
+		project = stored
+	}
+
+	tempDir, err := os.MkdirTemp("", "repo-*")
+	if err != nil {
+		return project, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	b.publish(DeployEvent{Stage: StageClone, Stream: "cloning repository", Time: time.Now().Unix()})
+
+	commitHash, err := d.clone(ctx, project, tempDir)
+	if err != nil {
+		return project, fmt.Errorf("failed to clone repository: %w", err)
+	}
+
+	dockerfilePath := filepath.Join(tempDir, "Dockerfile")
+	if _, err := os.Stat(dockerfilePath); os.IsNotExist(err) {
+		return project, fmt.Errorf("dockerfile not found in repository")
+	}
+
+	imageName := imageNameFromURL(project.URL)
+	imageFullName := fmt.Sprintf("%s:%s", imageName, commitHash)
+
+	if err := d.build(ctx, b, tempDir, imageName, imageFullName); err != nil {
+		return project, fmt.Errorf("failed to build image: %w", err)
+	}
+
+	previousContainerID := project.DockerContainerID
+	previousPort := project.Port
+
+	port, err := d.reservePort(ctx)
+	if err != nil {
+		return project, fmt.Errorf("failed to reserve port: %w", err)
+	}
+
+	b.publish(DeployEvent{Stage: StageRun, Stream: "starting container", Time: time.Now().Unix()})
+
+	containerID, err := d.run(ctx, imageFullName, port)
+	if err != nil {
+		d.releasePort(context.WithoutCancel(ctx), port)
+		return project, fmt.Errorf("failed to run container: %w", err)
+	}
+
+	// Новый контейнер должен ответить на "/", прежде чем он заменит старый в
+	// маршрутизации — так переключение происходит без окна простоя.
+	if err := d.awaitHealthy(ctx, port); err != nil {
+		_ = d.docker.ContainerRemove(context.WithoutCancel(ctx), containerID, container.RemoveOptions{Force: true})
+		d.releasePort(context.WithoutCancel(ctx), port)
+		return project, fmt.Errorf("new container failed health check: %w", err)
+	}
+
+	if d.proxy != nil && project.Domain != "" {
+		if err := d.proxy.SetRoute(project.Domain, fmt.Sprintf("127.0.0.1:%d", port)); err != nil {
+			_ = d.docker.ContainerRemove(context.WithoutCancel(ctx), containerID, container.RemoveOptions{Force: true})
+			d.releasePort(context.WithoutCancel(ctx), port)
+			return project, fmt.Errorf("failed to update proxy route: %w", err)
+		}
+	}
+
+	// Новый контейнер уже поднят, здоров и на него переключён прокси — деплой
+	// уже состоялся. Дальше персистим это состояние и лишь потом убираем
+	// старый контейнер/порт — иначе сбой уборки превратит успешный деплой в
+	// StageError и рассинхронизирует репозиторий с тем, что реально раздаёт
+	// трафик.
+	project.DockerContainerID = containerID
+	project.Port = port
+
+	if d.repo != nil && project.ID != uuid.Nil {
+		if err := d.repo.UpdateProject(ctx, project); err != nil {
+			return project, fmt.Errorf("failed to persist deployed project: %w", err)
+		}
+	}
+
+	if previousContainerID != "" {
+		if err := d.docker.ContainerRemove(context.WithoutCancel(ctx), previousContainerID, container.RemoveOptions{Force: true}); err != nil {
+			slog.Warn("failed to remove previous container", "container_id", previousContainerID, "error", err)
+		}
+	}
+
+	if previousPort != 0 {
+		d.releasePort(context.WithoutCancel(ctx), previousPort)
+	}
+
+	return project, nil
+}
+
+// reservePort резервирует порт хоста через репозиторий, чтобы выбор свободного
+// порта и его занятие были атомарны. Если репозиторий не задан, откатывается
+// на обычное сканирование ОС-портов — без персистентной резервации, но без
+// жёсткой зависимости от хранилища.
+func (d *Deploy) reservePort(ctx context.Context) (int, error) {
+	if d.repo == nil {
+		return findFreePort()
+	}
+
+	return d.repo.ReservePort(ctx)
+}
+
+// releasePort возвращает порт в пул, если резервации ведёт репозиторий.
+// Ошибка освобождения не должна заваливать уже успешный деплой, поэтому она
+// намеренно проглатывается — лишний повод упасть хуже, чем подвисший порт.
+func (d *Deploy) releasePort(ctx context.Context, port int) {
+	if d.repo == nil {
+		return
+	}
+
+	_ = d.repo.ReleasePort(ctx, port)
+}
+
+// awaitHealthy опрашивает "/" на локальном порту контейнера, пока тот не
+// ответит или не закончатся попытки.
+func (d *Deploy) awaitHealthy(ctx context.Context, port int) error {
+	addr := fmt.Sprintf("http://127.0.0.1:%d/", port)
+
+	var lastErr error
+	for i := 0; i < healthCheckAttempts; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			lastErr = err
+		} else {
+			resp.Body.Close()
+
+			if resp.StatusCode < http.StatusInternalServerError {
+				return nil
+			}
+
+			lastErr = fmt.Errorf("unhealthy status: %d", resp.StatusCode)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(healthCheckInterval):
+		}
+	}
+
+	return fmt.Errorf("no healthy response after %d attempts: %w", healthCheckAttempts, lastErr)
+}
+
+// clone клонирует репозиторий проекта в dir и возвращает короткий хэш HEAD.
+func (d *Deploy) clone(ctx context.Context, project entity.Project, dir string) (string, error) {
+	opts := &git.CloneOptions{
+		URL: project.URL,
+	}
+
+	if project.AccessToken != "" {
+		opts.Auth = &githttp.BasicAuth{
+			Username: "x-access-token", // игнорируется GitHub/GitLab, токен передаётся паролем
+			Password: project.AccessToken,
+		}
+	}
+
+	if project.Branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(project.Branch)
+		opts.SingleBranch = true
+	}
+
+	repo, err := git.PlainCloneContext(ctx, dir, false, opts)
+	if err != nil {
+		return "", err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+
+	return head.Hash().String()[:7], nil
+}
+
+// dockerBuildMessage — одна строка JSONL-потока, который Docker Engine
+// возвращает из ImageBuild (аналог вывода `docker build --progress=plain`).
+type dockerBuildMessage struct {
+	Stream         string          `json:"stream"`
+	Status         string          `json:"status"`
+	Error          string          `json:"error"`
+	ProgressDetail *ProgressDetail `json:"progressDetail"`
+}
+
+// buildCacheTag — тег, под которым каждая сборка образа репозитория заодно
+// публикуется локально, чтобы следующая сборка того же репозитория могла
+// взять с него слои через CacheFrom — это и есть --cache-to/--cache-from
+// BuildKit, но ключом кэша служит сам imageName, без отдельного registry.
+const buildCacheTag = "cache"
+
+// build собирает Docker-образ из каталога dir через Docker Engine API с
+// включённым BuildKit (аналог `DOCKER_BUILDKIT=1 docker build`), публикуя
+// каждую строку вывода сборки в b.
+func (d *Deploy) build(ctx context.Context, b *broker, dir, imageName, imageFullName string) error {
+	buildContext, err := archive.TarWithOptions(dir, &archive.TarOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to tar build context: %w", err)
+	}
+	defer buildContext.Close()
+
+	cacheImage := imageName + ":" + buildCacheTag
+
+	// BuildQueue сериализует сборки по Project.ID, но два проекта с разными
+	// ID могут указывать на один и тот же репозиторий (например, разные ветки)
+	// и делить один и тот же cacheImage — лочим по нему отдельно, чтобы такие
+	// сборки не затирали кэш друг друга.
+	lock := d.cacheLock(cacheImage)
+	lock.Lock()
+	defer lock.Unlock()
+
+	resp, err := d.docker.ImageBuild(ctx, buildContext, types.ImageBuildOptions{
+		Tags:       []string{imageFullName, cacheImage},
+		Dockerfile: "Dockerfile",
+		Remove:     true,
+		Version:    types.BuilderBuildKit,
+		CacheFrom:  []string{cacheImage},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg dockerBuildMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to read build output: %w", err)
+		}
+
+		if msg.Error != "" {
+			return fmt.Errorf("docker build: %s", msg.Error)
+		}
+
+		b.publish(DeployEvent{
+			Stage:          StageBuild,
+			Stream:         msg.Stream + msg.Status,
+			ProgressDetail: msg.ProgressDetail,
+			Time:           time.Now().Unix(),
+		})
+	}
+}
+
+// run создаёт и запускает контейнер из образа imageFullName, публикуя порт 80 на port хоста.
+func (d *Deploy) run(ctx context.Context, imageFullName string, port int) (string, error) {
+	containerPort, err := nat.NewPort("tcp", "80")
+	if err != nil {
+		return "", fmt.Errorf("failed to build container port: %w", err)
+	}
+
+	resp, err := d.docker.ContainerCreate(ctx,
+		&container.Config{Image: imageFullName},
+		&container.HostConfig{
+			PortBindings: nat.PortMap{
+				containerPort: []nat.PortBinding{{HostPort: strconv.Itoa(port)}},
+			},
+		},
+		nil, nil, "")
+	if err != nil {
+		return "", err
+	}
+
+	if err := d.docker.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return "", err
+	}
+
+	return resp.ID, nil
+}
+
+// cacheLock возвращает мьютекс, общий для всех сборок с одним и тем же
+// cacheImage, создавая его при первом обращении.
+func (d *Deploy) cacheLock(cacheImage string) *sync.Mutex {
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+
+	lock, ok := d.cacheLocks[cacheImage]
+	if !ok {
+		lock = &sync.Mutex{}
+		d.cacheLocks[cacheImage] = lock
+	}
+
+	return lock
+}
+
+// imageNameFromURL выводит имя Docker-образа из ссылки на репозиторий.
+func imageNameFromURL(repoURL string) string {
+	name := strings.TrimSuffix(repoURL, ".git")
+	name = strings.TrimPrefix(name, "https://")
+	name = strings.TrimPrefix(name, "http://")
+
+	return strings.ToLower(name)
+}
+
+// newDeployID генерирует уникальный ID для запуска деплоя.
+func newDeployID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.Must(uuid.NewV4()).String()
+	}
+
+	return id.String()
+}
+
+// findFreePort находит свободный порт, начиная с 3000.
+func findFreePort() (int, error) {
+	for port := 3000; port <= 65535; port++ {
+		addr := fmt.Sprintf(":%d", port)
+		listener, err := net.Listen("tcp", addr)
+		if err == nil {
+			listener.Close()
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free ports found")
+}
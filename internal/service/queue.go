@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gofrs/uuid/v5"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository"
+)
+
+// BuildQueue планирует запуски Deploy.Run: ограничивает число одновременных
+// сборок воркер-пулом, не даёт двум сборкам одного проекта идти параллельно
+// (иначе они гоняются за один и тот же тег образа и порт) и умеет отменить
+// ещё не начавшуюся или уже идущую сборку по её ID.
+type BuildQueue struct {
+	deploy *Deploy
+	repo   repository.Repository
+	sem    chan struct{}
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+	active  map[uuid.UUID]string
+	waiting map[uuid.UUID][]queuedBuild
+}
+
+// queuedBuild — сборка, ждущая своей очереди за уже идущей сборкой того же проекта.
+type queuedBuild struct {
+	id      string
+	project entity.Project
+}
+
+// NewBuildQueue создаёт очередь сборок с воркер-пулом размера workers (не меньше 1).
+func NewBuildQueue(deploy *Deploy, repo repository.Repository, workers int) *BuildQueue {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &BuildQueue{
+		deploy:  deploy,
+		repo:    repo,
+		sem:     make(chan struct{}, workers),
+		cancels: make(map[string]context.CancelFunc),
+		active:  make(map[uuid.UUID]string),
+		waiting: make(map[uuid.UUID][]queuedBuild),
+	}
+}
+
+// Enqueue ставит деплой проекта в очередь и сразу возвращает его ID. Если для
+// project.ID уже идёт или ждёт своей очереди сборка, возвращает её ID вместо
+// того, чтобы запускать ещё одну, — так залп параллельных запросов по одному
+// проекту (например, от дублирующихся push-вебхуков) схлопывается в одну
+// сборку. Проекты без ID (разовый запуск по голой ссылке) не дедуплицируются —
+// каждый такой запрос получает собственную сборку, ограниченную только пулом.
+func (q *BuildQueue) Enqueue(ctx context.Context, project entity.Project) (string, error) {
+	dedupe := project.ID != uuid.Nil
+
+	if dedupe {
+		if id, ok := q.existing(project.ID); ok {
+			return id, nil
+		}
+	}
+
+	id := newDeployID()
+
+	if q.repo != nil {
+		if err := q.repo.CreateBuild(ctx, entity.Build{ID: id, ProjectID: project.ID, Status: entity.BuildStatusQueued}); err != nil {
+			return "", fmt.Errorf("failed to persist queued build: %w", err)
+		}
+	}
+
+	q.mu.Lock()
+	if dedupe {
+		if _, busy := q.active[project.ID]; busy {
+			q.waiting[project.ID] = append(q.waiting[project.ID], queuedBuild{id: id, project: project})
+			q.mu.Unlock()
+			return id, nil
+		}
+
+		q.active[project.ID] = id
+	}
+	q.mu.Unlock()
+
+	q.dispatch(id, project)
+
+	return id, nil
+}
+
+// existing возвращает ID сборки проекта, если она уже идёт или ждёт очереди.
+func (q *BuildQueue) existing(projectID uuid.UUID) (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if id, ok := q.active[projectID]; ok {
+		return id, true
+	}
+
+	if jobs, ok := q.waiting[projectID]; ok && len(jobs) > 0 {
+		return jobs[0].id, true
+	}
+
+	return "", false
+}
+
+// Cancel прерывает сборку id: если она уже выполняется — отменяет её контекст,
+// если ещё ждёт своей очереди — снимает её из очереди, так и не запустив.
+// Возвращает false, если сборка с таким ID не найдена ни там, ни там.
+func (q *BuildQueue) Cancel(id string) bool {
+	q.mu.Lock()
+	cancel, ok := q.cancels[id]
+	q.mu.Unlock()
+
+	if ok {
+		cancel()
+		return true
+	}
+
+	q.mu.Lock()
+	for projectID, jobs := range q.waiting {
+		for i, job := range jobs {
+			if job.id != id {
+				continue
+			}
+
+			jobs = append(jobs[:i], jobs[i+1:]...)
+			if len(jobs) == 0 {
+				delete(q.waiting, projectID)
+			} else {
+				q.waiting[projectID] = jobs
+			}
+			q.mu.Unlock()
+
+			if q.repo != nil {
+				_ = q.repo.DeleteBuild(context.Background(), id)
+			}
+
+			return true
+		}
+	}
+	q.mu.Unlock()
+
+	return false
+}
+
+// Resume подхватывает сборки, оставшиеся в хранилище от предыдущего запуска
+// процесса (например, после падения или рестарта), и снова ставит их в очередь.
+// Сборки, чей проект не найден в хранилище (в том числе разовые запуски без
+// Project.ID), удаляются без запуска.
+func (q *BuildQueue) Resume(ctx context.Context) error {
+	if q.repo == nil {
+		return nil
+	}
+
+	builds, err := q.repo.PendingBuilds(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load pending builds: %w", err)
+	}
+
+	for _, build := range builds {
+		project, err := q.repo.ProjectByID(ctx, build.ProjectID)
+		if err != nil {
+			_ = q.repo.DeleteBuild(ctx, build.ID)
+			continue
+		}
+
+		q.mu.Lock()
+		if _, busy := q.active[project.ID]; busy {
+			q.waiting[project.ID] = append(q.waiting[project.ID], queuedBuild{id: build.ID, project: project})
+			q.mu.Unlock()
+			continue
+		}
+
+		q.active[project.ID] = build.ID
+		q.mu.Unlock()
+
+		q.dispatch(build.ID, project)
+	}
+
+	return nil
+}
+
+// dispatch резервирует слот в воркер-пуле и запускает Deploy.Run в фоне.
+// Отмена регистрируется до захвата слота, чтобы Cancel достал и сборку,
+// которая ещё только ждёт освобождения воркера.
+func (q *BuildQueue) dispatch(id string, project entity.Project) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q.mu.Lock()
+	q.cancels[id] = cancel
+	q.mu.Unlock()
+
+	go func() {
+		select {
+		case q.sem <- struct{}{}:
+		case <-ctx.Done():
+			q.finish(id, project)
+			return
+		}
+		defer func() { <-q.sem }()
+
+		if q.repo != nil {
+			_ = q.repo.UpdateBuildStatus(context.Background(), id, entity.BuildStatusRunning)
+		}
+
+		q.deploy.Run(ctx, id, project)
+
+		q.finish(id, project)
+	}()
+}
+
+// finish снимает регистрацию отмены сборки id, убирает её персистентную
+// запись и продвигает очередь проекта.
+func (q *BuildQueue) finish(id string, project entity.Project) {
+	q.mu.Lock()
+	delete(q.cancels, id)
+	q.mu.Unlock()
+
+	if q.repo != nil {
+		_ = q.repo.DeleteBuild(context.Background(), id)
+	}
+
+	if project.ID != uuid.Nil {
+		q.advance(project.ID)
+	}
+}
+
+// advance запускает следующую сборку проекта из очереди ожидания, если она
+// есть, иначе освобождает проект для новых сборок.
+func (q *BuildQueue) advance(projectID uuid.UUID) {
+	q.mu.Lock()
+	jobs := q.waiting[projectID]
+	if len(jobs) == 0 {
+		delete(q.active, projectID)
+		delete(q.waiting, projectID)
+		q.mu.Unlock()
+		return
+	}
+
+	next := jobs[0]
+	if len(jobs) == 1 {
+		delete(q.waiting, projectID)
+	} else {
+		q.waiting[projectID] = jobs[1:]
+	}
+	q.active[projectID] = next.id
+	q.mu.Unlock()
+
+	q.dispatch(next.id, next.project)
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+)
+
+// failingDeploy собирает Deploy без docker/repo/proxy. Клонирование несуществующего
+// локального пути падает сразу же, без сети, так что Run доходит до StageError
+// достаточно быстро для теста.
+func failingDeploy() *Deploy {
+	return NewDeploy(nil, nil, nil)
+}
+
+func TestBuildQueueEnqueueCoalescesByProjectID(t *testing.T) {
+	q := NewBuildQueue(failingDeploy(), nil, 1)
+
+	project := entity.Project{ID: uuid.Must(uuid.NewV4()), URL: "/no/such/repo"}
+
+	firstID, err := q.Enqueue(context.Background(), project)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	secondID, err := q.Enqueue(context.Background(), project)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if firstID != secondID {
+		t.Fatalf("expected second Enqueue for the same project to reuse build %q, got %q", firstID, secondID)
+	}
+}
+
+func TestBuildQueueEnqueueDoesNotCoalesceProjectsWithoutID(t *testing.T) {
+	q := NewBuildQueue(failingDeploy(), nil, 1)
+
+	project := entity.Project{URL: "/no/such/repo"}
+
+	firstID, err := q.Enqueue(context.Background(), project)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	secondID, err := q.Enqueue(context.Background(), project)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if firstID == secondID {
+		t.Fatalf("expected projects without an ID to get distinct builds, both got %q", firstID)
+	}
+}
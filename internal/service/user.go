@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/entity"
+	"gitlab.com/anaxita-server/easy-deploy/internal/proxy"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository"
+)
+
+// ErrInvalidCredentials возвращается при неверном email или пароле.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrProjectQuotaExceeded возвращается, когда пользователь упирается в лимит
+// одновременных проектов своего тарифа.
+var ErrProjectQuotaExceeded = errors.New("project quota exceeded")
+
+// freeProjectQuota — сколько проектов одновременно может держать пользователь на PlanFree.
+const freeProjectQuota = 1
+
+// User отвечает за регистрацию, аутентификацию и CRUD проектов пользователя.
+type User struct {
+	repo  repository.Repository
+	proxy *proxy.Proxy
+}
+
+// NewUser создаёт сервис пользователей поверх репозитория. rp может быть nil,
+// тогда удаление проекта не трогает таблицу маршрутизации прокси.
+func NewUser(repo repository.Repository, rp *proxy.Proxy) *User {
+	return &User{repo: repo, proxy: rp}
+}
+
+// Register хеширует пароль bcrypt'ом ( // Хешируем ) и сохраняет нового пользователя на PlanFree.
+func (u *User) Register(ctx context.Context, email, password string) (entity.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := entity.User{
+		ID:       newID(),
+		Email:    email,
+		Password: string(hash),
+		Plan:     entity.PlanFree,
+	}
+
+	if err := u.repo.CreateUser(ctx, user); err != nil {
+		return entity.User{}, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
+
+// Login проверяет email и пароль и возвращает пользователя при совпадении.
+func (u *User) Login(ctx context.Context, email, password string) (entity.User, error) {
+	user, err := u.repo.UserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return entity.User{}, ErrInvalidCredentials
+		}
+
+		return entity.User{}, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return entity.User{}, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// CreateProject создаёт проект пользователя, если тот не упёрся в квоту своего тарифа.
+func (u *User) CreateProject(ctx context.Context, user entity.User, project entity.Project) (entity.Project, error) {
+	quota := quotaByPlan(user.Plan)
+	if quota >= 0 {
+		count, err := u.repo.CountProjectsByUserID(ctx, user.ID)
+		if err != nil {
+			return entity.Project{}, fmt.Errorf("failed to count projects: %w", err)
+		}
+
+		if count >= quota {
+			return entity.Project{}, ErrProjectQuotaExceeded
+		}
+	}
+
+	project.ID = newID()
+	project.UserID = user.ID
+
+	if err := u.repo.CreateProject(ctx, project); err != nil {
+		return entity.Project{}, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return project, nil
+}
+
+// Project возвращает проект пользователя по ID.
+func (u *User) Project(ctx context.Context, id uuid.UUID) (entity.Project, error) {
+	return u.repo.ProjectByID(ctx, id)
+}
+
+// ProjectByURLAndBranch ищет проект по ссылке на репозиторий и ветке — так
+// вебхук резолвит пришедший push в конкретный проект.
+func (u *User) ProjectByURLAndBranch(ctx context.Context, url, branch string) (entity.Project, error) {
+	return u.repo.ProjectByURLAndBranch(ctx, url, branch)
+}
+
+// Projects возвращает все проекты пользователя.
+func (u *User) Projects(ctx context.Context, userID uuid.UUID) ([]entity.Project, error) {
+	return u.repo.ProjectsByUserID(ctx, userID)
+}
+
+// UpdateProject сохраняет изменённый проект.
+func (u *User) UpdateProject(ctx context.Context, project entity.Project) error {
+	return u.repo.UpdateProject(ctx, project)
+}
+
+// DeleteProject удаляет проект пользователя по ID и освобождает его маршрут в прокси.
+func (u *User) DeleteProject(ctx context.Context, id uuid.UUID) error {
+	if u.proxy != nil {
+		if project, err := u.repo.ProjectByID(ctx, id); err == nil && project.Domain != "" {
+			u.proxy.RemoveRoute(project.Domain)
+		}
+	}
+
+	return u.repo.DeleteProject(ctx, id)
+}
+
+// quotaByPlan — сколько проектов одновременно разрешено тарифу; -1 значит без ограничения.
+func quotaByPlan(plan entity.Plan) int {
+	if plan == entity.PlanPremium {
+		return -1
+	}
+
+	return freeProjectQuota
+}
+
+// newID генерирует UUIDv7 для новых сущностей, с откатом на v4, если время недоступно.
+func newID() uuid.UUID {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.Must(uuid.NewV4())
+	}
+
+	return id
+}
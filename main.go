@@ -1,217 +1,144 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"net"
 	"net/http"
-	"net/url"
 	"os"
-	"os/exec"
 	"os/signal"
-	"path/filepath"
-	"strconv"
-	"strings"
 	"syscall"
-	"time"
+
+	"github.com/docker/docker/client"
+
+	"gitlab.com/anaxita-server/easy-deploy/internal/api"
+	"gitlab.com/anaxita-server/easy-deploy/internal/proxy"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository/postgres"
+	"gitlab.com/anaxita-server/easy-deploy/internal/repository/sqlite"
+	"gitlab.com/anaxita-server/easy-deploy/internal/service"
 )
 
 type Config struct {
-	HTTPPort int `json:"http_port"`
+	HTTPPort int    `json:"http_port"`
+	DBDriver string `json:"db_driver"` // "sqlite" (по умолчанию) или "postgres"
+	DBDSN    string `json:"db_dsn"`
+
+	// ProxyEnabled включает обратный прокси, который раздаёт проекты на их
+	// Project.Domain с автоматическим TLS. Слушает ProxyHTTPPort/ProxyHTTPSPort,
+	// поэтому HTTPPort стоит вынести на отдельный от 80/443 порт.
+	ProxyEnabled   bool   `json:"proxy_enabled"`
+	ProxyHTTPPort  int    `json:"proxy_http_port"`
+	ProxyHTTPSPort int    `json:"proxy_https_port"`
+	CertCacheDir   string `json:"cert_cache_dir"`
+
+	// BuildWorkers — сколько сборок может идти одновременно; остальные ждут
+	// своей очереди в service.BuildQueue.
+	BuildWorkers int `json:"build_workers"`
 }
 
 var logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-// RequestPayload представляет JSON-данные из запроса.
-type RequestPayload struct {
-	URL string `json:"url"`
-}
+func parseJsonConfig(configPath string) (config Config, err error) {
+	config.HTTPPort = 80
+	config.DBDriver = "sqlite"
+	config.DBDSN = "easy-deploy.db"
+	config.ProxyHTTPPort = 80
+	config.ProxyHTTPSPort = 443
+	config.CertCacheDir = "certs"
+	config.BuildWorkers = 2
 
-// CloneAndBuild выполняет клонирование репозитория, сборку Docker-образа и запуск контейнера.
-func CloneAndBuild(repoURL *url.URL) error {
-	// Создание временного каталога для клонирования репозитория
-	tempDir, err := os.MkdirTemp("", "repo-*")
+	configFile, err := os.Open(configPath)
 	if err != nil {
-		return fmt.Errorf("failed to create temp dir: %w", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	// Клонирование репозитория
-	logger.Info("cloning repository", "repo_url", repoURL.String(), "temp_dir", tempDir)
-
-	cloneCmd := exec.Command("git", "clone", repoURL.String(), tempDir)
-	if b, err := cloneCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to clone repository: %w: %s", err, string(b))
-	}
-
-	logger.Info("Repository cloned")
-
-	// Проверка наличия Dockerfile
-	dockerfilePath := filepath.Join(tempDir, "Dockerfile")
-	if _, err := os.Stat(dockerfilePath); os.IsNotExist(err) {
-		return fmt.Errorf("dockerfile not found in repository")
-	}
-
-	logger.Info("dockerfile found", "path", dockerfilePath)
+		if errors.Is(err, os.ErrNotExist) {
+			return config, nil
+		}
 
-	// Получение хэша последнего коммита
-	lastCommitHashCmd := exec.Command("git", "-C", tempDir, "rev-parse", "--short", "HEAD")
-	b, err := lastCommitHashCmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to get last commit hash: %w: %s", err, string(b))
+		return Config{}, fmt.Errorf("failed to open config file: %w", err)
 	}
+	defer configFile.Close()
 
-	lastCommitShortHash := strings.TrimSpace(string(b))
-
-	logger.Info("Last commit hash", "hash", lastCommitShortHash)
-
-	// Сборка Docker-образа
-	imageName := repoURL.Host + repoURL.Path
-	imageTag := lastCommitShortHash
-	imageFullName := fmt.Sprintf("%s:%s", imageName, imageTag)
-
-	logger.Info("Building Docker image")
-	buildCmd := exec.Command("docker", "build", "-t", imageFullName, tempDir)
-	if b, err := buildCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to build Docker image: %w: %s", err, string(b))
+	if err := json.NewDecoder(configFile).Decode(&config); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	logger.Info("Docker image built", "image", imageName)
+	return config, nil
+}
 
-	logger.Info("Checking if container is running")
-	isContainerRunning := exec.Command("docker", "ps", "-q", "--filter", fmt.Sprintf("ancestor=%s", imageName))
-	b, err = isContainerRunning.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to check if container is running: %w: %s", err, string(b))
+// newRepository открывает хранилище, выбранное в конфиге (по умолчанию — sqlite).
+func newRepository(c Config) (repository.Repository, error) {
+	switch c.DBDriver {
+	case "", "sqlite":
+		return sqlite.New(c.DBDSN)
+	case "postgres":
+		return postgres.New(c.DBDSN)
+	default:
+		return nil, fmt.Errorf("unknown db driver: %q", c.DBDriver)
 	}
+}
 
-	containerID := strings.TrimSpace(string(b))
-
-	var port int
-	if containerID != "" {
-		logger.Info("Container is running", "containerID", containerID)
-
-		portCmd := exec.Command("docker", "port", containerID)
-		b, err := portCmd.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("failed to get container port: %w: %s", err, string(b))
+// startProxyListeners запускает в фоне слушатели обратного прокси: 80-й порт
+// обслуживает ACME http-01 challenge, 443-й — проксирует трафик проектов по TLS.
+func startProxyListeners(rp *proxy.Proxy, c Config) {
+	go func() {
+		addr := fmt.Sprintf(":%d", c.ProxyHTTPPort)
+		if err := http.ListenAndServe(addr, rp.HTTPHandler(nil)); err != nil {
+			logger.Error("proxy http listener failed", "error", err)
 		}
+	}()
 
-		b = bytes.TrimSpace(b)
-
-		port, err = strconv.Atoi(strings.Split(string(b), ":")[1])
-		if err != nil {
-			return fmt.Errorf("failed to parse container port: %w", err)
+	go func() {
+		server := &http.Server{
+			Addr:      fmt.Sprintf(":%d", c.ProxyHTTPSPort),
+			Handler:   rp,
+			TLSConfig: rp.TLSConfig(),
 		}
 
-		// Удаление контейнера
-		logger.Info("Removing container")
-		rmCmd := exec.Command("docker", "rm", "-f", containerID)
-		if b, err := rmCmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("failed to remove container: %w: %s", err, string(b))
+		if err := server.ListenAndServeTLS("", ""); err != nil {
+			logger.Error("proxy https listener failed", "error", err)
 		}
-	} else {
-		logger.Info("Container is not running, searching for free port")
-		// Поиск свободного порта
-		port, err = findFreePort()
-		if err != nil {
-			return fmt.Errorf("failed to find free port: %w", err)
-		}
-
-		logger.Info("Found free port", slog.Int("port", port))
-	}
-
-	// Запуск Docker-контейнера
-	logger.Info("Running Docker container")
-	runCmd := exec.Command("docker", "run", "-d", "-p", fmt.Sprintf("%d:80", port), imageFullName)
-	if err := runCmd.Run(); err != nil {
-		return fmt.Errorf("failed to run Docker container: %w", err)
-	}
-
-	logger.Info("Successfully ran Docker container", slog.Int("port", port))
-
-	return nil
-}
-
-// findFreePort находит свободный порт, начиная с 3000.
-func findFreePort() (int, error) {
-	for port := 3000; port <= 65535; port++ {
-		addr := fmt.Sprintf(":%d", port)
-		listener, err := net.Listen("tcp", addr)
-		if err == nil {
-			listener.Close()
-			return port, nil
-		}
-	}
-	return 0, fmt.Errorf("no free ports found")
+	}()
 }
 
-// handleDeploy обрабатывает HTTP-запросы.
-func handleDeploy(w http.ResponseWriter, r *http.Request) {
-	var payload RequestPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
-		return
-	}
-
-	logger.Info("Received request", "url", payload.URL)
-
-	parsedURL, err := url.Parse(payload.URL)
+func main() {
+	c, err := parseJsonConfig("config.json")
 	if err != nil {
-		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		logger.Error("failed to parse config", "error", err)
 		return
 	}
 
-	if err := CloneAndBuild(parsedURL); err != nil {
-		logger.Error("clone and build", "error", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	docker, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		logger.Error("failed to create docker client", "error", err)
 		return
 	}
+	defer docker.Close()
 
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte("Repository successfully processed"))
-}
-
-func parseJsonConfig(configPath string) (config Config, err error) {
-	config.HTTPPort = 80
-
-	configFile, err := os.Open(configPath)
+	repo, err := newRepository(c)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return config, nil
-		}
-
-		return Config{}, fmt.Errorf("failed to open config file: %w", err)
+		logger.Error("failed to open repository", "error", err)
+		return
 	}
-	defer configFile.Close()
 
-	if err := json.NewDecoder(configFile).Decode(&config); err != nil {
-		return Config{}, fmt.Errorf("failed to parse config file: %w", err)
+	var rp *proxy.Proxy
+	if c.ProxyEnabled {
+		rp = proxy.New(c.CertCacheDir)
+		startProxyListeners(rp, c)
 	}
 
-	return config, nil
-}
-
-func main() {
-	c, err := parseJsonConfig("config.json")
-	if err != nil {
-		logger.Error("failed to parse config", "error", err)
-		return
+	deploy := service.NewDeploy(docker, repo, rp)
+	queue := service.NewBuildQueue(deploy, repo, c.BuildWorkers)
+	if err := queue.Resume(context.Background()); err != nil {
+		logger.Error("failed to resume build queue", "error", err)
 	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("POST /deploy", handleDeploy)
+	users := service.NewUser(repo, rp)
+	handler := api.NewHandler(deploy, queue, users)
+	mux := api.NewMux(handler)
 
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", c.HTTPPort),
-		Handler:      CorsMiddleware(mux),
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-	}
+	server := api.NewServer(c.HTTPPort, CorsMiddleware(mux))
 
 	logger.Info("Starting server", "address", server.Addr)
 